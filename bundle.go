@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// resolveLocalDenoExe returns the path to a local deno binary usable for
+// `deno bundle`/`deno vendor`, preferring LocalDenoBin and falling back to
+// $PATH. The result is cached on the config.
+func (p *Provisioner) resolveLocalDenoExe() (string, error) {
+	if p.config.localDenoExe != "" {
+		return p.config.localDenoExe, nil
+	}
+
+	if p.config.LocalDenoBin != "" {
+		p.config.localDenoExe = p.config.LocalDenoBin
+		return p.config.localDenoExe, nil
+	}
+
+	path, err := exec.LookPath("deno")
+	if err != nil {
+		return "", err
+	}
+	p.config.localDenoExe = path
+	return path, nil
+}
+
+// bundleScript runs `deno bundle` locally on src, returning the path to a
+// temporary single-file JS bundle. The caller is responsible for removing
+// the returned file.
+func (p *Provisioner) bundleScript(src string) (string, error) {
+	denoExe, err := p.resolveLocalDenoExe()
+	if err != nil {
+		return "", fmt.Errorf("local deno binary required to bundle: %s", err)
+	}
+
+	tmp, err := os.CreateTemp("", "packer-deno-bundle-*.js")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+
+	args := []string{"bundle"}
+	if p.config.ImportMap != "" {
+		args = append(args, "--import-map="+p.config.ImportMap)
+	}
+	args = append(args, src, tmp.Name())
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(denoExe, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("deno bundle %s: %s: %s", src, err, stderr.String())
+	}
+
+	return tmp.Name(), nil
+}
+
+// vendorDeno runs `deno vendor` locally against our scripts, writing its
+// output to VendorDir, then uploads the resulting vendor tree to the
+// target so remote imports resolve without reaching deno.land/x.
+func (p *Provisioner) vendorDeno(ctx context.Context, ui packer.Ui, comm packer.Communicator) error {
+	denoExe, err := p.resolveLocalDenoExe()
+	if err != nil {
+		return fmt.Errorf("local deno binary required for vendor_dir: %s", err)
+	}
+
+	args := []string{"vendor", "--force", "--output", p.config.VendorDir}
+	for _, spec := range p.config.scripts {
+		args = append(args, spec.Path)
+	}
+
+	ui.Message("Running deno vendor")
+	var stderr bytes.Buffer
+	cmd := exec.Command(denoExe, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("deno vendor: %s: %s", err, stderr.String())
+	}
+
+	dst := remoteJoin(p.config.targetOS, p.config.RemoteFolder, "vendor")
+	ui.Message(fmt.Sprintf("Uploading vendor tree to %s", dst))
+	if err := p.uploadDir(ctx, ui, comm, dst, p.config.VendorDir); err != nil {
+		return err
+	}
+
+	// `deno vendor` writes an import_map.json at the root of its output
+	// that remaps deno.land/x imports to the vendored copies. Use it as
+	// the default import map for remote runs that don't set their own,
+	// so scripts actually resolve against the uploaded vendor tree
+	// instead of reaching deno.land/x.
+	if p.config.ImportMap == "" {
+		p.config.remoteImportMap = remoteJoin(p.config.targetOS, dst, "import_map.json")
+	}
+	return nil
+}
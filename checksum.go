@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// sha256File returns the lowercase hex SHA-256 digest of the file at path.
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// verifyRemoteSHA256 checksums path on the remote target and compares it
+// against expected, failing if they don't match.
+func (p *Provisioner) verifyRemoteSHA256(ctx context.Context, ui packer.Ui, comm packer.Communicator, path, expected string) error {
+	ui.Message(fmt.Sprintf("Verifying checksum of %s", path))
+
+	var out bytes.Buffer
+	var commandString string
+	if p.config.targetOS == "windows" {
+		// path may be "$env:USERPROFILE\..."; quoting it would suppress
+		// that expansion, so leave it bare like runDeno does.
+		commandString = fmt.Sprintf(`(Get-FileHash -Algorithm SHA256 %s).Hash`, path)
+	} else {
+		commandString = fmt.Sprintf("sha256sum %s | cut -d ' ' -f 1", path)
+	}
+
+	cmd := &packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString), Stdout: &out}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return fmt.Errorf("checksumming %s: %s", path, err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("checksumming %s: %s", path, err)
+	}
+	if code := cmd.ExitStatus(); code != 0 {
+		return fmt.Errorf("checksumming %s: non-zero exit status: %d", path, code)
+	}
+
+	actual := strings.TrimSpace(out.String())
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%s has sha256 %s, expected %s", path, actual, expected)
+	}
+	return nil
+}
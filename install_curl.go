@@ -32,6 +32,14 @@ fi
 echo "OS DETECTED: $OS $VER"
 echo ""
 
+# Run privileged commands with sudo when we're not already root; many
+# Packer communicators connect as an unprivileged SSH user.
+if [ "$(id -u)" != "0" ]; then
+  SUDO=$(command -v sudo || true)
+else
+  SUDO=""
+fi
+
 # Use our corny method of installing curl: look for
 # well-known package managers and call them.
 
@@ -39,15 +47,30 @@ if ! [ -x "$(command -v curl)" ]; then
   echo "curl executable not detected"
   if [ -x "$(command -v apt-get)" ]; then
     echo 'using apt-get'
-    apt-get update
-    apt-get install -y curl
+    $SUDO apt-get update
+    $SUDO apt-get install -y curl
+  elif [ -x "$(command -v dnf)" ]; then
+    echo "using dnf"
+    $SUDO dnf install -y curl
   elif [ -x "$(command -v yum)" ]; then
     echo "using yum"
-    yum update
-    yum install -y curl
+    $SUDO yum update
+    $SUDO yum install -y curl
+  elif [ -x "$(command -v zypper)" ]; then
+    echo "using zypper"
+    $SUDO zypper --non-interactive install curl
+  elif [ -x "$(command -v pacman)" ]; then
+    echo "using pacman"
+    $SUDO pacman -Sy --noconfirm curl
   elif [ -x "$(command -v apk)" ]; then
     echo "using apk"
-    apk add --no-cache curl
+    $SUDO apk add --no-cache curl
+  elif [ -x "$(command -v pkg)" ]; then
+    echo "using pkg"
+    $SUDO pkg install -y curl
+  elif [ -x "$(command -v brew)" ]; then
+    echo "using brew"
+    brew install curl
   else
     echo "package manager not detected"
     exit 1
@@ -60,4 +83,3 @@ if ! [ -x "$(command -v curl)" ]; then
 fi
 
 `
-
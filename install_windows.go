@@ -0,0 +1,14 @@
+package main
+
+// installPowerShellScript bootstraps Deno on a Windows target using the
+// official PowerShell installer, mirroring installCurlScript's role on
+// POSIX targets.
+var installPowerShellScript = `
+param([string]$Version)
+
+$ErrorActionPreference = "Stop"
+if ($Version) {
+    $v = $Version
+}
+iwr https://deno.land/install.ps1 -useb | iex
+`
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/packer/helper/config"
 	"github.com/hashicorp/packer/packer"
@@ -24,24 +25,123 @@ type DenoConfig struct {
 	// RemoteFolder instead of using an install command/script.
 	LocalDenoBin string `mapstructure:"local_deno_bin"`
 
+	// DenoVersion pins the installed Deno release, e.g. "1.17.0". If
+	// empty, the installer picks whatever the latest release is.
+	DenoVersion string `mapstructure:"deno_version"`
+
+	// DenoSHA256 is the expected SHA-256 checksum of the installed,
+	// unpacked deno binary (or of LocalDenoBin) - NOT the checksum of the
+	// release archive published on deno.land/releases, which covers the
+	// zipped download rather than the extracted executable. If set, the
+	// provisioner verifies it after install and fails the build on
+	// mismatch.
+	DenoSHA256 string `mapstructure:"deno_sha256"`
+
 	// If true, do not install Deno on remote target. Assume it is already there.
 	SkipInstall bool
 
 	// For testing purposes, we can skip provisioning and just look at how deno was installed
 	SkipProvision bool `mapstructure:"skip_provision"`
 
-	// The destination folder for uploaded Deno scripts.
+	// The destination folder for uploaded Deno scripts. Defaults to
+	// "/tmp/packer-deno", or "C:\Windows\Temp\packer-deno" once the
+	// target is known to be Windows.
 	RemoteFolder string `mapstructure:"remote_folder"`
 
-	// A slice of scripts to compile and run.
-	Scripts []string
-
-	// Path to the deno executable on the remote target; TODO make configurable
+	// TargetOS is the OS of the machine being provisioned: "linux",
+	// "darwin", "windows", or "auto" (the default) to detect it via a
+	// probe command run through the communicator.
+	TargetOS string `mapstructure:"target_os"`
+
+	// targetOS holds the resolved form of TargetOS ("windows" or a POSIX
+	// flavor), populated once detection runs in Provision.
+	targetOS string
+
+	// A slice of scripts to upload and run. Each entry may be a plain
+	// string (a path, run with no extra flags) or a map matching
+	// ScriptSpec, to control permissions and flags per-script.
+	Scripts []interface{}
+
+	// scripts holds the decoded/validated form of Scripts, populated by Prepare.
+	scripts []ScriptSpec
+
+	// A slice of modules/scripts to install as globally-available
+	// executables via `deno install`, each with its own permission set.
+	Installs []InstallSpec
+
+	// Tests is a slice of local paths (files or directories) containing
+	// Deno test suites. After scripts and installs run, each is uploaded
+	// and run via `deno test --allow-all`, failing the build on a
+	// non-zero exit - an in-image smoke test gate before snapshotting.
+	Tests []string `mapstructure:"tests"`
+
+	// LintPaths is a slice of local paths (files or directories) linted
+	// via `deno lint` after scripts and installs run, failing the build
+	// on lint errors.
+	LintPaths []string `mapstructure:"lint_paths"`
+
+	// Bundle controls whether each script is compiled to a single-file JS
+	// bundle locally (via `deno bundle`) before upload, so the target
+	// doesn't need network access to deno.land/x at provision time.
+	// Defaults to true when a local deno binary can be found, either via
+	// LocalDenoBin or on $PATH.
+	Bundle *bool `mapstructure:"bundle"`
+
+	// bundle holds the resolved value of Bundle, populated by Prepare.
+	bundle bool
+
+	// VendorDir, if set, is a local directory that `deno vendor` writes
+	// its output to; the resulting vendor tree is uploaded alongside
+	// scripts so the target can resolve remote imports without reaching
+	// deno.land/x.
+	VendorDir string `mapstructure:"vendor_dir"`
+
+	// ImportMap is a default import map uploaded and passed as
+	// --import-map= to remote `deno run` invocations whose ScriptSpec
+	// doesn't set its own.
+	ImportMap string `mapstructure:"import_map"`
+
+	// remoteImportMap is the uploaded path of ImportMap, populated by
+	// Provision. If ImportMap is empty and VendorDir is set, Provision
+	// falls back to the vendored import_map.json instead, so remote runs
+	// still resolve against the uploaded vendor tree.
+	remoteImportMap string
+
+	// localDenoExe caches the local deno binary resolved for
+	// bundle/vendor, either LocalDenoBin or whatever's on $PATH.
+	localDenoExe string
+
+	// InstallPrefix is the bin directory deno is installed to and run
+	// from on a POSIX target, e.g. "${HOME}/.local/bin". It's expanded by
+	// the remote shell, not by this plugin, so the same config works
+	// regardless of which user the communicator connects as. Defaults to
+	// "${HOME}/.local/bin". Ignored when the target is Windows.
+	InstallPrefix string `mapstructure:"install_prefix"`
+
+	// Path to the deno executable on the remote target.
 	denoExecutable string
 
 	ctx interpolate.Context
 }
 
+// InstallSpec describes a single `deno install` target: a module URL or a
+// local script to upload, turned into a globally-available executable on
+// the target machine.
+type InstallSpec struct {
+	// Module is a module URL (e.g. "https://deno.land/std/examples/welcome.ts")
+	// or a local path to a script to upload and install.
+	Module string
+
+	// Name of the resulting executable (--name). Required.
+	Name string
+
+	// Root is the install directory (--root). If empty, Deno's own default
+	// install root is used.
+	Root string
+
+	PermissionSet `mapstructure:",squash"`
+}
+
 // Provisioner implements a Packer Provisioner
 type Provisioner struct {
 	config DenoConfig
@@ -72,20 +172,31 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		return err
 	}
 
-	if p.config.RemoteFolder == "" {
-		p.config.RemoteFolder = "/tmp/packer-deno"
-	}
+	var errs *packer.MultiError
 
-	if p.config.Scripts == nil {
-		p.config.Scripts = make([]string, 0)
+	for i, raw := range p.config.Scripts {
+		spec, err := decodeScriptSpec(raw)
+		if err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("scripts[%d]: %s", i, err))
+			continue
+		}
+		p.config.scripts = append(p.config.scripts, spec)
 	}
 
-	var errs *packer.MultiError
-
 	if p.config.LocalDenoBin != "" {
 		if _, err := os.Stat(p.config.LocalDenoBin); err != nil {
 			errs = packer.MultiErrorAppend(errs,
 				fmt.Errorf("bad path to local deno binary '%s': %s", p.config.LocalDenoBin, err))
+		} else if p.config.DenoSHA256 != "" {
+			sum, err := sha256File(p.config.LocalDenoBin)
+			if err != nil {
+				errs = packer.MultiErrorAppend(errs,
+					fmt.Errorf("checksumming local deno binary '%s': %s", p.config.LocalDenoBin, err))
+			} else if !strings.EqualFold(sum, p.config.DenoSHA256) {
+				errs = packer.MultiErrorAppend(errs,
+					fmt.Errorf("local deno binary '%s' has sha256 %s, expected %s", p.config.LocalDenoBin, sum, p.config.DenoSHA256))
+			}
 		}
 		if p.config.SkipInstall {
 			errs = packer.MultiErrorAppend(errs,
@@ -93,22 +204,92 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		}
 	}
 
-	// TODO find a way to install deno to different places/users/globally
-	p.config.denoExecutable = "/root/.local/bin/deno"
-	if !filepath.IsAbs(p.config.denoExecutable) {
+	if p.config.ImportMap != "" {
+		if _, err := os.Stat(p.config.ImportMap); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("bad import_map '%s': %s", p.config.ImportMap, err))
+		}
+	}
+
+	_, localDenoErr := p.resolveLocalDenoExe()
+	if p.config.Bundle != nil {
+		p.config.bundle = *p.config.Bundle
+		if p.config.bundle && localDenoErr != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("bundle is enabled but no local deno binary was found: %s", localDenoErr))
+		}
+	} else {
+		p.config.bundle = localDenoErr == nil
+	}
+
+	if p.config.VendorDir != "" && localDenoErr != nil {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("vendor_dir is set but no local deno binary was found: %s", localDenoErr))
+	}
+
+	switch p.config.TargetOS {
+	case "", "auto", "linux", "darwin", "windows":
+	default:
 		errs = packer.MultiErrorAppend(errs,
-			errors.New("remote target denoExecutable must be an absolute path"))
+			fmt.Errorf("target_os must be one of linux, darwin, windows, or auto, got %q", p.config.TargetOS))
+	}
+
+	if p.config.InstallPrefix == "" {
+		p.config.InstallPrefix = "${HOME}/.local/bin"
+	}
+
+	// If the target OS is known up front, resolve denoExecutable and
+	// RemoteFolder now; "auto" defers resolution to Provision, once a
+	// probe command through the communicator tells us what we're
+	// provisioning.
+	switch p.config.TargetOS {
+	case "windows":
+		p.config.targetOS = "windows"
+		p.config.denoExecutable = `$env:USERPROFILE\.deno\bin\deno.exe`
+	case "", "auto":
+		// resolved during Provision
+	default:
+		p.config.targetOS = p.config.TargetOS
+		p.config.denoExecutable = remoteJoin(p.config.targetOS, p.config.InstallPrefix, "deno")
+	}
+	if p.config.RemoteFolder == "" && p.config.targetOS != "" {
+		p.config.RemoteFolder = defaultRemoteFolder(p.config.targetOS)
 	}
 
-	if len(p.config.Scripts) == 0 {
+	if len(p.config.scripts) == 0 && len(p.config.Installs) == 0 {
 		errs = packer.MultiErrorAppend(errs,
-			errors.New("at least one script must be specified"))
+			errors.New("at least one script or install must be specified"))
 	}
 
-	for _, path := range p.config.Scripts {
+	for _, spec := range p.config.scripts {
+		if _, err := os.Stat(spec.Path); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("bad script '%s': %s", spec.Path, err))
+		}
+	}
+
+	for i, install := range p.config.Installs {
+		if install.Name == "" {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("installs[%d]: name is required", i))
+		}
+		if install.Module == "" {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("installs[%d]: module is required", i))
+		}
+	}
+
+	for _, path := range p.config.Tests {
 		if _, err := os.Stat(path); err != nil {
 			errs = packer.MultiErrorAppend(errs,
-				fmt.Errorf("bad script '%s': %s", path, err))
+				fmt.Errorf("bad test '%s': %s", path, err))
+		}
+	}
+
+	for _, path := range p.config.LintPaths {
+		if _, err := os.Stat(path); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("bad lint path '%s': %s", path, err))
 		}
 	}
 
@@ -123,10 +304,32 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.Communicator) error {
 	ui.Say("Provisioning with Deno")
 
+	if p.config.targetOS == "" {
+		detected, err := p.detectTargetOS(ctx, comm)
+		if err != nil {
+			return fmt.Errorf("error detecting target OS: %s", err)
+		}
+		ui.Message(fmt.Sprintf("Detected target OS: %s", detected))
+		p.config.targetOS = detected
+		if detected == "windows" {
+			p.config.denoExecutable = `$env:USERPROFILE\.deno\bin\deno.exe`
+		} else {
+			p.config.denoExecutable = remoteJoin(p.config.targetOS, p.config.InstallPrefix, "deno")
+		}
+		if p.config.RemoteFolder == "" {
+			p.config.RemoteFolder = defaultRemoteFolder(p.config.targetOS)
+		}
+	}
+
 	if !p.config.SkipInstall {
 		if p.config.LocalDenoBin == "" {
-			// Use curl to install deno
-			if err := p.curlInstallDeno(ctx, ui, comm); err != nil {
+			var err error
+			if p.config.targetOS == "windows" {
+				err = p.powerShellInstallDeno(ctx, ui, comm)
+			} else {
+				err = p.curlInstallDeno(ctx, ui, comm)
+			}
+			if err != nil {
 				return fmt.Errorf("error installing deno: %s", err)
 			}
 		} else {
@@ -138,30 +341,62 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 		ui.Message("Skipping Deno installation")
 	}
 
-	// TODO: compile deno bundles locally, before upload
-	// Once built-in bundling is available, this will become a lot easier:
-	// https://github.com/denoland/deno/issues/2357
-
 	ui.Say("Uploading deno scripts...")
 	if err := p.createDir(ctx, ui, comm, p.config.RemoteFolder); err != nil {
 		return fmt.Errorf("error creating remote directory: %s", err)
 	}
 
-	var remoteScripts []string
+	if p.config.ImportMap != "" {
+		dst := remoteJoin(p.config.targetOS, p.config.RemoteFolder, filepath.Base(p.config.ImportMap))
+		ui.Message(fmt.Sprintf("Uploading %s", p.config.ImportMap))
+		if err := p.uploadFile(ctx, ui, comm, dst, p.config.ImportMap); err != nil {
+			return fmt.Errorf("error uploading import map: %s", err)
+		}
+		p.config.remoteImportMap = dst
+	}
+
+	if p.config.VendorDir != "" {
+		if err := p.vendorDeno(ctx, ui, comm); err != nil {
+			return fmt.Errorf("error vendoring deno dependencies: %s", err)
+		}
+	}
+
+	type remoteScript struct {
+		spec ScriptSpec
+		path string
+	}
+
+	var remoteScripts []remoteScript
+
+	for _, spec := range p.config.scripts {
+		src := spec.Path
+
+		if p.config.bundle {
+			bundled, err := p.bundleScript(src)
+			if err != nil {
+				return fmt.Errorf("error bundling %s: %s", src, err)
+			}
+			defer os.Remove(bundled)
+			src = bundled
+		}
 
-	for _, src := range p.config.Scripts {
 		s, err := os.Stat(src)
 		if err != nil {
 			return fmt.Errorf("stat error: %s", err)
 		}
 
+		base := filepath.Base(spec.Path)
+		if p.config.bundle {
+			base = strings.TrimSuffix(base, filepath.Ext(base)) + ".js"
+		}
+
 		if s.Mode().IsRegular() {
 			ui.Message(fmt.Sprintf("Uploading %s", src))
-			dst := filepath.ToSlash(filepath.Join(p.config.RemoteFolder, filepath.Base(src)))
+			dst := remoteJoin(p.config.targetOS, p.config.RemoteFolder, base)
 			if err := p.uploadFile(ctx, ui, comm, dst, src); err != nil {
 				return fmt.Errorf("error uploading deno script: %s", err)
 			}
-			remoteScripts = append(remoteScripts, dst)
+			remoteScripts = append(remoteScripts, remoteScript{spec: spec, path: dst})
 		} else if s.Mode().IsDir() {
 			return fmt.Errorf("%s is a directory, expected deno script", src)
 		} else {
@@ -173,7 +408,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 
 		ui.Say("Running provisioning scripts")
 		for _, script := range remoteScripts {
-			if err := p.runDeno(ctx, ui, comm, script); err != nil {
+			if err := p.runDeno(ctx, ui, comm, script.spec, script.path); err != nil {
 				return fmt.Errorf("error running deno: %s", err)
 			}
 		}
@@ -181,6 +416,101 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 		ui.Say("Skipping provisioning scripts")
 	}
 
+	if len(p.config.Installs) > 0 {
+		ui.Say("Installing deno executables...")
+		for _, install := range p.config.Installs {
+			module := install.Module
+			if s, err := os.Stat(install.Module); err == nil && s.Mode().IsRegular() {
+				dst := remoteJoin(p.config.targetOS, p.config.RemoteFolder, filepath.Base(install.Module))
+				ui.Message(fmt.Sprintf("Uploading %s", install.Module))
+				if err := p.uploadFile(ctx, ui, comm, dst, install.Module); err != nil {
+					return fmt.Errorf("error uploading install script: %s", err)
+				}
+				module = dst
+			}
+			if err := p.runDenoInstall(ctx, ui, comm, install, module); err != nil {
+				return fmt.Errorf("error installing %s: %s", install.Name, err)
+			}
+		}
+	}
+
+	if len(p.config.Tests) > 0 {
+		ui.Say("Running deno test...")
+		for _, path := range p.config.Tests {
+			dst, err := p.uploadPath(ctx, ui, comm, path)
+			if err != nil {
+				return fmt.Errorf("error uploading test '%s': %s", path, err)
+			}
+			if err := p.runDenoTest(ctx, ui, comm, dst); err != nil {
+				return fmt.Errorf("error running deno test on '%s': %s", path, err)
+			}
+		}
+	}
+
+	if len(p.config.LintPaths) > 0 {
+		ui.Say("Running deno lint...")
+		for _, path := range p.config.LintPaths {
+			dst, err := p.uploadPath(ctx, ui, comm, path)
+			if err != nil {
+				return fmt.Errorf("error uploading lint path '%s': %s", path, err)
+			}
+			if err := p.runDenoLint(ctx, ui, comm, dst); err != nil {
+				return fmt.Errorf("error running deno lint on '%s': %s", path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// uploadPath uploads a local file or directory under RemoteFolder, returning
+// its remote path. Used by the test/lint gates, which accept either a single
+// script or a directory of them.
+func (p *Provisioner) uploadPath(ctx context.Context, ui packer.Ui, comm packer.Communicator, src string) (string, error) {
+	s, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+
+	dst := remoteJoin(p.config.targetOS, p.config.RemoteFolder, filepath.Base(src))
+	if s.Mode().IsDir() {
+		ui.Message(fmt.Sprintf("Uploading %s", src))
+		if err := p.uploadDir(ctx, ui, comm, dst, src); err != nil {
+			return "", err
+		}
+	} else {
+		ui.Message(fmt.Sprintf("Uploading %s", src))
+		if err := p.uploadFile(ctx, ui, comm, dst, src); err != nil {
+			return "", err
+		}
+	}
+	return dst, nil
+}
+
+// runDenoTest runs `deno test --allow-all` against an uploaded test path,
+// failing the build on a non-zero exit - an in-image smoke test gate before
+// the image is snapshotted.
+func (p *Provisioner) runDenoTest(ctx context.Context, ui packer.Ui, comm packer.Communicator, path string) error {
+	args := []string{p.config.denoExecutable, "test", "--allow-all", path}
+	commandString := strings.Join(args, " ")
+	ui.Say(commandString)
+	cmd := packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString)}
+	if err := execRemoteCommand(ctx, comm, &cmd, ui, commandString); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runDenoLint runs `deno lint` against an uploaded path, failing the build
+// on any lint error.
+func (p *Provisioner) runDenoLint(ctx context.Context, ui packer.Ui, comm packer.Communicator, path string) error {
+	args := []string{p.config.denoExecutable, "lint", path}
+	commandString := strings.Join(args, " ")
+	ui.Say(commandString)
+	cmd := packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString)}
+	if err := execRemoteCommand(ctx, comm, &cmd, ui, commandString); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -206,30 +536,153 @@ func (p *Provisioner) curlInstallDeno(ctx context.Context, ui packer.Ui, comm pa
 		return err
 	}
 
+	// The installer drops deno at ${DENO_INSTALL:-$HOME/.deno}/bin/deno,
+	// so DENO_INSTALL must be pointed at the parent of InstallPrefix
+	// (where denoExecutable expects to find it) or the two disagree.
+	denoInstallDir := remoteDir(p.config.targetOS, p.config.InstallPrefix)
 	bootstrapURL := "https://deno.land/x/install/install.sh"
-	cmd = packer.RemoteCmd{Command: fmt.Sprintf("curl -fsSL %s | sh", bootstrapURL)}
+	installCmd := fmt.Sprintf("export DENO_INSTALL=%s && curl -fsSL %s | sh", denoInstallDir, bootstrapURL)
+	if p.config.DenoVersion != "" {
+		installCmd = fmt.Sprintf("%s -s -- v%s", installCmd, p.config.DenoVersion)
+	}
+	cmd = packer.RemoteCmd{Command: installCmd}
 	ui.Message("Downloading and executing deno installer script")
 	if err := execRemoteCommand(ctx, comm, &cmd, ui, "installer script"); err != nil {
 		return err
 	}
 
+	if p.config.DenoSHA256 != "" {
+		if err := p.verifyRemoteSHA256(ctx, ui, comm, p.config.denoExecutable, p.config.DenoSHA256); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// localBinInstallDeno uploads LocalDenoBin to denoExecutable. comm.Upload is
+// a raw SFTP/WinRM file transfer with no shell involved, so the "${HOME}"/
+// "$env:USERPROFILE" left in denoExecutable for shell commands is never
+// expanded for it; resolve it to a concrete remote path first.
 func (p *Provisioner) localBinInstallDeno(ctx context.Context, ui packer.Ui, comm packer.Communicator) error {
-	if err := p.createDir(ctx, ui, comm, filepath.Dir(p.config.denoExecutable)); err != nil {
+	dst, err := p.resolveRemotePath(ctx, comm, p.config.denoExecutable)
+	if err != nil {
+		return fmt.Errorf("resolving remote deno path: %v", err)
+	}
+
+	if err := p.createDir(ctx, ui, comm, remoteDir(p.config.targetOS, dst)); err != nil {
 		return fmt.Errorf("mkdir for local deno bin on remote machine: %v", err)
 	}
-	if err := p.uploadFile(ctx, ui, comm, p.config.denoExecutable, p.config.LocalDenoBin); err != nil {
+	if err := p.uploadFile(ctx, ui, comm, dst, p.config.LocalDenoBin); err != nil {
 		return fmt.Errorf("upload local deno bin: %v", err)
 	}
-	cmd := packer.RemoteCmd{Command: fmt.Sprintf("chmod +x %s", p.config.denoExecutable)}
+	if p.config.DenoSHA256 != "" {
+		if err := p.verifyRemoteSHA256(ctx, ui, comm, dst, p.config.DenoSHA256); err != nil {
+			return err
+		}
+	}
+	if p.config.targetOS == "windows" {
+		// No executable bit on Windows; the .exe extension is enough.
+		return nil
+	}
+	cmd := packer.RemoteCmd{Command: fmt.Sprintf("chmod +x %s", dst)}
 	if err := execRemoteCommand(ctx, comm, &cmd, ui, "set executable bit"); err != nil {
 		return err
 	}
 	return nil
 }
 
+// resolveRemotePath probes the remote target's home directory and expands
+// any "${HOME}"/"$HOME" (POSIX) or "$env:USERPROFILE" (Windows) in path,
+// for use as a comm.Upload destination.
+func (p *Provisioner) resolveRemotePath(ctx context.Context, comm packer.Communicator, path string) (string, error) {
+	var homeVar, probeCmd string
+	if p.config.targetOS == "windows" {
+		homeVar, probeCmd = "$env:USERPROFILE", "echo $env:USERPROFILE"
+	} else {
+		homeVar, probeCmd = "${HOME}", "echo $HOME"
+	}
+	if !strings.Contains(path, homeVar) {
+		return path, nil
+	}
+
+	var out bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, probeCmd), Stdout: &out}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return "", fmt.Errorf("probing remote home directory: %s", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("probing remote home directory: %s", err)
+	}
+	if code := cmd.ExitStatus(); code != 0 {
+		return "", fmt.Errorf("probing remote home directory: non-zero exit status: %d", code)
+	}
+
+	home := strings.TrimSpace(out.String())
+	return strings.ReplaceAll(path, homeVar, home), nil
+}
+
+// powerShellInstallDeno installs deno on a Windows target using the
+// official PowerShell installer script.
+func (p *Provisioner) powerShellInstallDeno(ctx context.Context, ui packer.Ui, comm packer.Communicator) error {
+	buf := bytes.NewBuffer([]byte(installPowerShellScript))
+	if err := comm.Upload(`C:\Windows\Temp\install_deno.ps1`, buf, nil); err != nil {
+		return fmt.Errorf("error uploading powershell install script: %v", err)
+	}
+
+	installCmd := `powershell -ExecutionPolicy Bypass -File C:\Windows\Temp\install_deno.ps1`
+	if p.config.DenoVersion != "" {
+		installCmd = fmt.Sprintf("%s -Version %s", installCmd, p.config.DenoVersion)
+	}
+	cmd := packer.RemoteCmd{Command: installCmd}
+	ui.Message("Running powershell deno installer script")
+	if err := execRemoteCommand(ctx, comm, &cmd, ui, "powershell install script"); err != nil {
+		return err
+	}
+
+	if p.config.DenoSHA256 != "" {
+		if err := p.verifyRemoteSHA256(ctx, ui, comm, p.config.denoExecutable, p.config.DenoSHA256); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// detectTargetOS probes the remote target through the communicator to
+// determine its OS, used when TargetOS is unset or "auto". %OS% expands to
+// "Windows_NT" in cmd.exe; on a POSIX shell it is passed through literally,
+// so a non-Windows match falls through to a `uname -s` probe to tell darwin
+// apart from linux.
+func (p *Provisioner) detectTargetOS(ctx context.Context, comm packer.Communicator) (string, error) {
+	var out bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: "echo %OS%", Stdout: &out}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return "", fmt.Errorf("probing target OS: %s", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return "", fmt.Errorf("probing target OS: %s", err)
+	}
+
+	if strings.Contains(out.String(), "Windows_NT") {
+		return "windows", nil
+	}
+
+	var unameOut bytes.Buffer
+	unameCmd := &packer.RemoteCmd{Command: "uname -s", Stdout: &unameOut}
+	if err := comm.Start(ctx, unameCmd); err != nil {
+		return "", fmt.Errorf("probing target OS: %s", err)
+	}
+	if err := unameCmd.Wait(); err != nil {
+		return "", fmt.Errorf("probing target OS: %s", err)
+	}
+
+	if strings.Contains(unameOut.String(), "Darwin") {
+		return "darwin", nil
+	}
+	return "linux", nil
+}
+
 // execRemoteCommand executes a packer.RemoteCommand, blocks, and checks for exit code 0.
 func execRemoteCommand(ctx context.Context, comm packer.Communicator, cmd *packer.RemoteCmd, ui packer.Ui, msg string) error {
 	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
@@ -241,12 +694,45 @@ func execRemoteCommand(ctx context.Context, comm packer.Communicator, cmd *packe
 	return nil
 }
 
-// runDeno runs deno with our uploaded scripts
-func (p *Provisioner) runDeno(ctx context.Context, ui packer.Ui, comm packer.Communicator, scriptPath string) error {
-	commandString := fmt.Sprintf("%s run -A %s", p.config.denoExecutable, scriptPath)
+// runDeno runs deno with one of our uploaded scripts, applying the
+// permissions and flags configured on its ScriptSpec.
+func (p *Provisioner) runDeno(ctx context.Context, ui packer.Ui, comm packer.Communicator, spec ScriptSpec, scriptPath string) error {
+	flags, err := spec.flags(p.config.remoteImportMap)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{p.config.denoExecutable, "run"}, flags...)
+	args = append(args, scriptPath)
+	args = append(args, spec.Args...)
+
+	commandString := strings.Join(args, " ")
 	ui.Say(commandString)
-	cmd := packer.RemoteCmd{
-		Command: commandString}
+	cmd := packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString)}
+	if err := execRemoteCommand(ctx, comm, &cmd, ui, commandString); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runDenoInstall runs `deno install` for an InstallSpec, turning moduleRef
+// (a URL or an already-uploaded remote path) into a globally-available
+// executable on the target machine.
+func (p *Provisioner) runDenoInstall(ctx context.Context, ui packer.Ui, comm packer.Communicator, install InstallSpec, moduleRef string) error {
+	flags, err := install.PermissionSet.flags()
+	if err != nil {
+		return fmt.Errorf("install %s: %s", install.Name, err)
+	}
+
+	args := append([]string{p.config.denoExecutable, "install", "--force", "--name", install.Name}, flags...)
+	if install.Root != "" {
+		args = append(args, "--root", install.Root)
+	}
+	args = append(args, moduleRef)
+
+	commandString := strings.Join(args, " ")
+	ui.Say(commandString)
+	cmd := packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString)}
 	if err := execRemoteCommand(ctx, comm, &cmd, ui, commandString); err != nil {
 		return err
 	}
@@ -256,8 +742,15 @@ func (p *Provisioner) runDeno(ctx context.Context, ui packer.Ui, comm packer.Com
 // createDir creates a directory on the remote server
 func (p *Provisioner) createDir(ctx context.Context, ui packer.Ui, comm packer.Communicator, dir string) error {
 	ui.Message(fmt.Sprintf("Creating directory: %s", dir))
-	cmd := packer.RemoteCmd{Command: fmt.Sprintf("mkdir -p '%s'", dir)}
 
+	var commandString string
+	if p.config.targetOS == "windows" {
+		commandString = fmt.Sprintf(`New-Item -ItemType Directory -Force -Path '%s' | Out-Null`, dir)
+	} else {
+		commandString = fmt.Sprintf("mkdir -p '%s'", dir)
+	}
+
+	cmd := packer.RemoteCmd{Command: wrapRemoteCommand(p.config.targetOS, commandString)}
 	if err := execRemoteCommand(ctx, comm, &cmd, ui, "create dir"); err != nil {
 		return err
 	}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+)
+
+// PermissionSet describes the Deno permission flags to apply to a single
+// script or install target. Each Allow* field may be left unset (the
+// permission is denied), set to `true` (the permission is granted for
+// everything), or set to a whitelist of values - either a comma-separated
+// string or a list of strings - to scope the grant, e.g. allow_net:
+// "api.example.com,10.0.0.0/8". This mirrors how `deno run`/`deno install`
+// accept both bare `--allow-net` and scoped `--allow-net=host1,host2` flags.
+type PermissionSet struct {
+	AllowRead  interface{} `mapstructure:"allow_read"`
+	AllowWrite interface{} `mapstructure:"allow_write"`
+	AllowNet   interface{} `mapstructure:"allow_net"`
+	AllowEnv   interface{} `mapstructure:"allow_env"`
+	AllowRun   interface{} `mapstructure:"allow_run"`
+
+	// AllowHRTime is boolean-only in Deno; there is no whitelist form.
+	AllowHRTime bool `mapstructure:"allow_hrtime"`
+}
+
+// permFlagOrder mirrors the order Deno's own CLI lists permission flags in,
+// so generated commands read the same way a human would have typed them.
+var permFlagOrder = []string{"allow-read", "allow-write", "allow-net", "allow-env", "allow-run"}
+
+// flags renders the permission set into the `--allow-*` flags that
+// `deno run`/`deno install` expect, in Deno's own flag order.
+func (p PermissionSet) flags() ([]string, error) {
+	values := map[string]interface{}{
+		"allow-read":  p.AllowRead,
+		"allow-write": p.AllowWrite,
+		"allow-net":   p.AllowNet,
+		"allow-env":   p.AllowEnv,
+		"allow-run":   p.AllowRun,
+	}
+
+	var flags []string
+	for _, name := range permFlagOrder {
+		flag, ok, err := permFlag(name, values[name])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			flags = append(flags, flag)
+		}
+	}
+
+	if p.AllowHRTime {
+		flags = append(flags, "--allow-hrtime")
+	}
+
+	return flags, nil
+}
+
+// permFlag renders a single --allow-* flag from a config value that may be
+// a bool, a comma-separated string whitelist, or a list of whitelist
+// entries.
+func permFlag(name string, v interface{}) (flag string, ok bool, err error) {
+	switch t := v.(type) {
+	case nil:
+		return "", false, nil
+	case bool:
+		if !t {
+			return "", false, nil
+		}
+		return "--" + name, true, nil
+	case string:
+		if t == "" {
+			return "", false, nil
+		}
+		return fmt.Sprintf("--%s=%s", name, t), true, nil
+	case []string:
+		if len(t) == 0 {
+			return "", false, nil
+		}
+		return fmt.Sprintf("--%s=%s", name, join(t)), true, nil
+	case []interface{}:
+		whitelist := make([]string, 0, len(t))
+		for _, entry := range t {
+			s, ok := entry.(string)
+			if !ok {
+				return "", false, fmt.Errorf("%s: whitelist entries must be strings, got %T", name, entry)
+			}
+			whitelist = append(whitelist, s)
+		}
+		return permFlag(name, whitelist)
+	default:
+		return "", false, fmt.Errorf("%s: must be a bool or a comma-separated whitelist, got %T", name, v)
+	}
+}
+
+func join(ss []string) string {
+	out := ""
+	for i, s := range ss {
+		if i > 0 {
+			out += ","
+		}
+		out += s
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// remoteJoin joins path elements using the separator appropriate for
+// targetOS. filepath.Join always uses the Packer host's separator, which
+// is wrong when provisioning a Windows target from a non-Windows host (or
+// vice versa), so remote paths are built with this instead.
+func remoteJoin(targetOS string, elems ...string) string {
+	sep := "/"
+	if targetOS == "windows" {
+		sep = `\`
+	}
+	return strings.Join(elems, sep)
+}
+
+// remoteDir returns the containing directory of path, split using the
+// separator appropriate for targetOS (see remoteJoin).
+func remoteDir(targetOS, path string) string {
+	sep := "/"
+	if targetOS == "windows" {
+		sep = `\`
+	}
+	if i := strings.LastIndex(path, sep); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// defaultRemoteFolder returns the default RemoteFolder for targetOS, used
+// when the config doesn't set one explicitly.
+func defaultRemoteFolder(targetOS string) string {
+	if targetOS == "windows" {
+		return `C:\Windows\Temp\packer-deno`
+	}
+	return "/tmp/packer-deno"
+}
+
+// wrapRemoteCommand wraps commandString so it runs correctly on the target
+// shell: PowerShell on Windows, passed through unchanged on POSIX targets
+// where the communicator already runs commands via /bin/sh.
+func wrapRemoteCommand(targetOS, commandString string) string {
+	if targetOS == "windows" {
+		if strings.HasPrefix(commandString, "$") {
+			// A statement starting with a $-expression (e.g.
+			// denoExecutable expanding to $env:USERPROFILE\...) is
+			// parsed in expression mode and never invoked; the call
+			// operator forces PowerShell to run it as a command.
+			commandString = "& " + commandString
+		}
+		return fmt.Sprintf(`powershell -Command "%s"`, commandString)
+	}
+	return commandString
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ScriptSpec describes a single Deno script to upload and run, along with
+// the permissions and flags it should be run with. A plain string is also
+// accepted in config for backward compatibility, and is equivalent to
+// ScriptSpec{Path: <string>} with no permissions granted beyond Deno's
+// defaults.
+type ScriptSpec struct {
+	// Path is a local path to the script to upload and execute.
+	Path string
+
+	PermissionSet `mapstructure:",squash"`
+
+	Unstable   bool     `mapstructure:"unstable"`
+	ImportMap  string   `mapstructure:"import_map"`
+	Lock       string   `mapstructure:"lock"`
+	CachedOnly bool     `mapstructure:"cached_only"`
+	Reload     bool     `mapstructure:"reload"`
+	Args       []string `mapstructure:"args"`
+}
+
+// decodeScriptSpec turns one raw `scripts` entry - either a bare string or
+// a map of ScriptSpec fields - into a ScriptSpec.
+func decodeScriptSpec(raw interface{}) (ScriptSpec, error) {
+	switch v := raw.(type) {
+	case string:
+		return ScriptSpec{Path: v}, nil
+	case map[string]interface{}:
+		var spec ScriptSpec
+		if err := mapstructure.Decode(v, &spec); err != nil {
+			return ScriptSpec{}, fmt.Errorf("invalid script entry: %s", err)
+		}
+		return spec, nil
+	default:
+		return ScriptSpec{}, fmt.Errorf("script entry must be a string or a map, got %T", raw)
+	}
+}
+
+// flags renders the deno run flags for a script, in the order Deno's own
+// CLI expects: permissions, then the unstable/import-map/lock/caching
+// flags, then the script path, then any user-supplied args. defaultImportMap
+// is used when the script doesn't specify its own import_map - the remote
+// path of DenoConfig.ImportMap, if one was uploaded.
+func (s ScriptSpec) flags(defaultImportMap string) ([]string, error) {
+	flags, err := s.PermissionSet.flags()
+	if err != nil {
+		return nil, fmt.Errorf("script '%s': %s", s.Path, err)
+	}
+
+	if s.Unstable {
+		flags = append(flags, "--unstable")
+	}
+	importMap := s.ImportMap
+	if importMap == "" {
+		importMap = defaultImportMap
+	}
+	if importMap != "" {
+		flags = append(flags, "--import-map="+importMap)
+	}
+	if s.Lock != "" {
+		flags = append(flags, "--lock="+s.Lock)
+	}
+	if s.CachedOnly {
+		flags = append(flags, "--cached-only")
+	}
+	if s.Reload {
+		flags = append(flags, "--reload")
+	}
+
+	return flags, nil
+}